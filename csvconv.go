@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"math"
+	"os"
 	"strconv"
 )
 
@@ -17,35 +18,123 @@ var (
 )
 
 type Reader struct {
-	reader    *csv.Reader
-	headerSet bool
-	header    []string
-	nCols     int
+	reader     *csv.Reader
+	in         io.Reader
+	sep        rune
+	headerSet  bool
+	header     []string
+	rawHeader  []string
+	colIndex   []int
+	nCols      int
+	nullTokens []string
+	schema     []ColumnSchema
+	pending    [][]string
+	unflatten  *Flattener
+
+	selectCols  []string
+	renameMap   map[string]string
+	whereFn     func(record map[string]string) bool
+	limit       int
+	skip        int
+	rowsSeen    int
+	rowsEmitted int
 }
 
-// Read a record from the data
+// Read returns the next record, after applying any Where filter, Skip/Limit
+// bounds, and Select/Rename projection configured on r.
 func (r *Reader) Read() ([]string, error) {
 	// if the header has not been set, set it
 	if !r.headerSet {
-		r.setHeader()
+		if err := r.setHeader(); err != nil {
+			return []string{}, err
+		}
 	}
-	record, err := r.reader.Read()
+	if len(r.pending) > 0 {
+		record := r.pending[0]
+		r.pending = r.pending[1:]
+		return record, nil
+	}
+	return r.fetchNext()
+}
+
+// fetchNext applies the Where/Skip/Limit/Select pipeline to rows read
+// directly from the underlying csv.Reader, bypassing r.pending. InferSchema
+// uses this while sampling so it can push fully-processed rows onto pending
+// for later replay without Read() immediately handing the same row back.
+func (r *Reader) fetchNext() ([]string, error) {
+	raw, err := r.nextFilteredRaw(r.reader, &r.rowsSeen, &r.rowsEmitted)
 	if err != nil {
 		return []string{}, err
 	}
-	return record, nil
+	return r.project(raw), nil
+}
+
+// nextFilteredRaw reads raw (unprojected) CSV records from cr, applying the
+// Where/Skip/Limit pipeline against rowsSeen/rowsEmitted, and returns the
+// first record that passes. The counters are passed in rather than read off
+// r so that streamColumnsSeek can run the same pipeline once per column,
+// each pass with its own counters, over a fresh reader on the same input.
+func (r *Reader) nextFilteredRaw(cr *csv.Reader, rowsSeen, rowsEmitted *int) ([]string, error) {
+	for {
+		if r.limit >= 0 && *rowsEmitted >= r.limit {
+			return nil, io.EOF
+		}
+		raw, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		if *rowsSeen < r.skip {
+			*rowsSeen++
+			continue
+		}
+		*rowsSeen++
+		if r.whereFn != nil && !r.whereFn(r.rawRecord(raw)) {
+			continue
+		}
+		*rowsEmitted++
+		return raw, nil
+	}
+}
+
+// rawRecord returns raw keyed by the CSV's original header names, for use
+// by Where predicates regardless of any configured Select/Rename.
+func (r *Reader) rawRecord(raw []string) map[string]string {
+	m := make(map[string]string, len(raw))
+	for i, v := range raw {
+		if i < len(r.rawHeader) {
+			m[r.rawHeader[i]] = v
+		}
+	}
+	return m
+}
+
+// project applies r.colIndex (built from Select, or identity if unset) to
+// raw, producing the output-shaped record.
+func (r *Reader) project(raw []string) []string {
+	out := make([]string, len(r.colIndex))
+	for i, idx := range r.colIndex {
+		out[i] = raw[idx]
+	}
+	return out
 }
 
 // Create a csv converter reader
-func NewReader(in io.Reader, sep rune) *Reader {
-	r := csv.NewReader(in)
-	r.Comma = sep
-	r.TrimLeadingSpace = true
-	return &Reader{
-		reader:    r,
+func NewReader(in io.Reader, sep rune, opts ...ReaderOption) *Reader {
+	r := &Reader{
+		in:        in,
+		sep:       sep,
 		headerSet: false,
 		header:    []string{},
+		limit:     -1,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	cr := csv.NewReader(r.in)
+	cr.Comma = sep
+	cr.TrimLeadingSpace = true
+	r.reader = cr
+	return r
 }
 
 type colType int
@@ -64,12 +153,44 @@ func (r *Reader) setHeader() error {
 	if err != nil {
 		return err
 	}
-	r.nCols = len(record)
-	r.header = record
-	for i := range r.header {
-		r.header[i] = strconv.Quote(r.header[i])
+	if len(record) > 0 {
+		record[0] = stripBOM(record[0])
+	}
+	r.rawHeader = record
+	r.reader.FieldsPerRecord = len(record) // the underlying CSV keeps its original width
+
+	colIndex := make([]int, len(record))
+	for i := range colIndex {
+		colIndex[i] = i
 	}
-	r.reader.FieldsPerRecord = r.nCols // only allow n cols from now on
+	if r.selectCols != nil {
+		colIndex = make([]int, len(r.selectCols))
+		for i, name := range r.selectCols {
+			idx := -1
+			for j, h := range record {
+				if h == name {
+					idx = j
+					break
+				}
+			}
+			if idx < 0 {
+				return fmt.Errorf("csvconv: select: unknown column %q", name)
+			}
+			colIndex[i] = idx
+		}
+	}
+	r.colIndex = colIndex
+
+	header := make([]string, len(colIndex))
+	for i, idx := range colIndex {
+		name := record[idx]
+		if renamed, ok := r.renameMap[name]; ok {
+			name = renamed
+		}
+		header[i] = strconv.Quote(name)
+	}
+	r.header = header
+	r.nCols = len(header)
 	r.headerSet = true
 	return nil
 }
@@ -81,127 +202,274 @@ const (
 	OrientRecords
 )
 
-// Returns a jsonafiable object
-func (r *Reader) toJSONStruct(out io.Writer, orient JSONOrient, nRows int) (int, error) {
+// jsonCellValue renders a raw CSV cell as a JSON token: numbers are left
+// unquoted, empty cells become null, everything else is quoted.
+func jsonCellValue(val string) string {
+	if _, err := strconv.ParseFloat(val, 64); err == nil {
+		return val
+	}
+	if val == "" {
+		return "null"
+	}
+	return strconv.Quote(val)
+}
+
+// ToJSONStream reads up to nRows records and writes them as JSON directly to
+// w, never materializing the full dataset in memory. For OrientRecords rows
+// are written as they're read. For OrientColumns, which needs every value of
+// a column before the next can start, the underlying data is read once per
+// column when r.in supports io.Seeker, or buffered per-column in temp files
+// otherwise.
+func (r *Reader) ToJSONStream(w io.Writer, orient JSONOrient, nRows int) (int, error) {
 	if nRows < 0 {
 		nRows = math.MaxInt64
 	}
-	r.setHeader()
-	nRead := 0
-	var err error
+	if !r.headerSet {
+		if err := r.setHeader(); err != nil {
+			return 0, err
+		}
+	}
 	switch orient {
 	case OrientColumns:
-		data := make([][]string, r.nCols)
-		for colNum := range data {
-			data[colNum] = []string{}
-		}
-		for rowNum := 0; rowNum < nRows; rowNum++ {
-			record, err := r.Read()
-			if err != nil {
-				// hitting EOF is only an issue if i == 0
-				if rowNum == 0 || err != io.EOF {
-					return nRead, err
-				}
-				break
+		return r.streamColumns(w, nRows)
+	case OrientRecords:
+		return r.streamRecords(w, nRows)
+	default:
+		return 0, errors.New("Unknown orient method")
+	}
+}
+
+func (r *Reader) streamRecords(w io.Writer, nRows int) (int, error) {
+	nRead := 0
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nRead, err
+	}
+	for rowNum := 0; rowNum < nRows; rowNum++ {
+		record, err := r.Read()
+		if err != nil {
+			// hitting EOF is only an issue if rowNum == 0
+			if rowNum == 0 || err != io.EOF {
+				return nRead, err
 			}
-			nRead++
-			for colNum := range record {
-				data[colNum] = append(data[colNum], record[colNum])
+			break
+		}
+		if rowNum != 0 {
+			if _, err = io.WriteString(w, ","); err != nil {
+				return nRead, err
 			}
 		}
-		if _, err = io.WriteString(out, "{"); err != nil {
+		nRead++
+		if err = r.writeRecord(w, record); err != nil {
 			return nRead, err
 		}
-		for colNum := range data {
-			headerStr := r.header[colNum] + ":"
-			if _, err = io.WriteString(out, headerStr); err != nil {
-				return nRead, err
-			}
-			if _, err = io.WriteString(out, "["); err != nil {
-				return nRead, err
-			}
-			for rowNum := range data[colNum] {
-				val := data[colNum][rowNum]
-				if _, err = strconv.ParseFloat(val, 64); err != nil {
-					if val == "" {
-						val = "null"
-					} else {
-						val = strconv.Quote(val)
-					}
-				}
-				if _, err = io.WriteString(out, val); err != nil {
-					return nRead, err
-				}
-				if rowNum < len(data[colNum])-1 {
-					if _, err = io.WriteString(out, ","); err != nil {
-						return nRead, err
-					}
+	}
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return nRead, err
+	}
+	return nRead, nil
+}
+
+// writeRecord writes a single CSV record as a JSON object. If r.unflatten is
+// set, dotted headers (e.g. "user.name") are expanded into nested objects
+// first; otherwise the record is written flat, column by column.
+func (r *Reader) writeRecord(w io.Writer, record []string) error {
+	if r.unflatten == nil {
+		if _, err := io.WriteString(w, "{"); err != nil {
+			return err
+		}
+		for colNum, val := range record {
+			if colNum != 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
 				}
 			}
-			if _, err = out.Write([]byte("]")); err != nil {
-				return nRead, err
-			}
-			if colNum < len(data)-1 {
-				if _, err = out.Write([]byte(",")); err != nil {
-					return nRead, err
-				}
+			keyVal := r.header[colNum] + ":" + r.encodeCell(colNum, val)
+			if _, err := io.WriteString(w, keyVal); err != nil {
+				return err
 			}
 		}
-		if _, err = out.Write([]byte("}")); err != nil {
+		_, err := io.WriteString(w, "}")
+		return err
+	}
+
+	flat := map[string]interface{}{}
+	for colNum, val := range record {
+		name, err := strconv.Unquote(r.header[colNum])
+		if err != nil {
+			name = r.header[colNum]
+		}
+		var cell interface{}
+		if err := json.Unmarshal([]byte(r.encodeCell(colNum, val)), &cell); err != nil {
+			return err
+		}
+		flat[name] = cell
+	}
+	nested, err := json.Marshal(r.unflatten.Unflatten(flat))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(nested)
+	return err
+}
+
+// SetUnflatten makes ToJSON/ToJSONStream expand dotted column names (e.g.
+// "user.name") back into nested JSON objects, using f as the separator
+// config. Pass nil to disable. Only OrientRecords honors this; OrientColumns
+// ignores it, since a column-major layout has no notion of a nested row.
+func (r *Reader) SetUnflatten(f *Flattener) {
+	r.unflatten = f
+}
+
+func (r *Reader) streamColumns(w io.Writer, nRows int) (int, error) {
+	if seeker, ok := r.in.(io.Seeker); ok {
+		return r.streamColumnsSeek(w, seeker, nRows)
+	}
+	return r.streamColumnsTempFiles(w, nRows)
+}
+
+// streamColumnsSeek re-reads the underlying input once per column, so only
+// one column's worth of values is ever resident in memory.
+func (r *Reader) streamColumnsSeek(w io.Writer, seeker io.Seeker, nRows int) (int, error) {
+	nRead := 0
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nRead, err
+	}
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return nRead, err
+	}
+	for colNum := 0; colNum < r.nCols; colNum++ {
+		cr, err := r.columnPassReader(seeker)
+		if err != nil {
 			return nRead, err
 		}
-		return nRead, nil
-	case OrientRecords:
-		if _, err = io.WriteString(out, "["); err != nil {
+		if _, err = io.WriteString(w, r.header[colNum]+":["); err != nil {
 			return nRead, err
 		}
+		rawCol := r.colIndex[colNum]
+		rowsSeen, rowsEmitted := 0, 0
+		rowsInCol := 0
 		for rowNum := 0; rowNum < nRows; rowNum++ {
-			record, err := r.Read()
+			raw, err := r.nextFilteredRaw(cr, &rowsSeen, &rowsEmitted)
 			if err != nil {
-				// hitting EOF is only an issue if i == 0
 				if rowNum == 0 || err != io.EOF {
 					return nRead, err
 				}
 				break
 			}
 			if rowNum != 0 {
-				if _, err = io.WriteString(out, ","); err != nil {
+				if _, err = io.WriteString(w, ","); err != nil {
 					return nRead, err
 				}
 			}
-			nRead++
-			if _, err = io.WriteString(out, "{"); err != nil {
+			if _, err = io.WriteString(w, r.encodeCell(colNum, raw[rawCol])); err != nil {
 				return nRead, err
 			}
-			for colNum, val := range record {
-				if colNum != 0 {
-					if _, err = io.WriteString(out, ","); err != nil {
-						return nRead, err
-					}
-				}
-				if _, err = strconv.ParseFloat(val, 64); err != nil {
-					if val == "" {
-						val = "null"
-					} else {
-						val = strconv.Quote(val)
-					}
-				}
-				keyVal := r.header[colNum] + ":" + val
-				if _, err = io.WriteString(out, keyVal); err != nil {
+			rowsInCol++
+		}
+		if _, err = io.WriteString(w, "]"); err != nil {
+			return nRead, err
+		}
+		if colNum < r.nCols-1 {
+			if _, err = io.WriteString(w, ","); err != nil {
+				return nRead, err
+			}
+		}
+		if colNum == 0 {
+			nRead = rowsInCol
+		}
+	}
+	if _, err := io.WriteString(w, "}"); err != nil {
+		return nRead, err
+	}
+	// leave the input where the caller's own reader expects it next
+	seeker.Seek(start, io.SeekStart)
+	return nRead, nil
+}
+
+// columnPassReader seeks back to the start of the input and returns a fresh
+// csv.Reader positioned just past the header row. It reads at the raw CSV's
+// own width, not the (possibly Select-projected) output width: the caller
+// picks out r.colIndex[colNum] from each raw record.
+func (r *Reader) columnPassReader(seeker io.Seeker) (*csv.Reader, error) {
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r.in)
+	cr.Comma = r.sep
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = len(r.rawHeader)
+	if _, err := cr.Read(); err != nil { // skip the header
+		return nil, err
+	}
+	return cr, nil
+}
+
+// streamColumnsTempFiles is the fallback for non-seekable input: it makes a
+// single pass over the data, spilling each column's values to its own temp
+// file, then stitches the temp files together into the final JSON object.
+func (r *Reader) streamColumnsTempFiles(w io.Writer, nRows int) (int, error) {
+	colFiles := make([]*os.File, r.nCols)
+	defer func() {
+		for _, f := range colFiles {
+			if f != nil {
+				f.Close()
+				os.Remove(f.Name())
+			}
+		}
+	}()
+	for i := range colFiles {
+		f, err := ioutil.TempFile("", "csvconv-col-*")
+		if err != nil {
+			return 0, err
+		}
+		colFiles[i] = f
+	}
+	nRead := 0
+	for rowNum := 0; rowNum < nRows; rowNum++ {
+		record, err := r.Read()
+		if err != nil {
+			if rowNum == 0 || err != io.EOF {
+				return nRead, err
+			}
+			break
+		}
+		for colNum, val := range record {
+			if rowNum != 0 {
+				if _, err = io.WriteString(colFiles[colNum], ","); err != nil {
 					return nRead, err
 				}
 			}
-			if _, err = io.WriteString(out, "}"); err != nil {
+			if _, err = io.WriteString(colFiles[colNum], r.encodeCell(colNum, val)); err != nil {
 				return nRead, err
 			}
 		}
-		if _, err = io.WriteString(out, "]"); err != nil {
+		nRead++
+	}
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return nRead, err
+	}
+	for colNum, f := range colFiles {
+		if _, err := io.WriteString(w, r.header[colNum]+":["); err != nil {
 			return nRead, err
 		}
-		return nRead, nil
-	default:
-		return 0, errors.New("Unknown orient method")
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nRead, err
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			return nRead, err
+		}
+		if _, err := io.WriteString(w, "]"); err != nil {
+			return nRead, err
+		}
+		if colNum < len(colFiles)-1 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return nRead, err
+			}
+		}
 	}
+	_, err := io.WriteString(w, "}")
+	return nRead, err
 }
 
 // Reads and converts CSV rows to JSON. err will be io.EOF if there where no
@@ -209,7 +477,7 @@ func (r *Reader) toJSONStruct(out io.Writer, orient JSONOrient, nRows int) (int,
 // than nRows read.
 func (r *Reader) ToJSON(orient JSONOrient, nRows int) (rowsRead int, jsonData []byte, err error) {
 	buf := bytes.NewBuffer([]byte{})
-	rowsRead, err = r.toJSONStruct(buf, orient, nRows)
+	rowsRead, err = r.ToJSONStream(buf, orient, nRows)
 	if err != nil {
 		return rowsRead, []byte{}, err
 	}
@@ -220,26 +488,59 @@ func (r *Reader) ToJSON(orient JSONOrient, nRows int) (rowsRead int, jsonData []
 type JSONReader struct {
 	headersSet      bool
 	expectedHeaders []string
+	flattener       *Flattener
+
+	selectCols []string
+	renameMap  map[string]string
+	whereFn    func(record map[string]interface{}) bool
+	limit      int
+	skip       int
 }
 
 func NewJSONReader() *JSONReader {
-	return &JSONReader{headersSet: false}
+	return &JSONReader{headersSet: false, limit: -1}
+}
+
+// SetFlattener makes ToCSV flatten each record's nested objects/arrays into
+// dotted columns (see Flattener) before writing. Pass nil to disable.
+func (d *JSONReader) SetFlattener(f *Flattener) {
+	d.flattener = f
 }
 
-func (d *JSONReader) ToCSV(r io.Reader, sep rune) ([]byte, error) {
-	data, err := ioutil.ReadAll(r)
+// ToCSV decodes r with a json.Decoder instead of reading the whole payload
+// into memory first. The first token tells us which orientation we're
+// dealing with: a '[' means records, a '{' means columns. Memory use is not
+// bounded: the decoded records (or columns) are all held until the CSV is
+// written, and the CSV output is returned as a single []byte.
+func (d *JSONReader) ToCSV(r io.Reader, sep rune, opts ...CSVOption) ([]byte, error) {
+	cfg := csvOutputConfig{encoding: EncodingUTF8}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
 	if err != nil {
 		return []byte{}, err
 	}
-	byRecord := []map[string]interface{}{}
-	if nil == json.Unmarshal(data, &byRecord) {
-		return d.parseJSONByRecord(byRecord, sep)
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return []byte{}, errors.New("JSON does not conform to CSV encodings")
 	}
-	byColumn := map[string][]interface{}{}
-	if nil != json.Unmarshal(data, &byColumn) {
+
+	var out []byte
+	switch delim {
+	case '[':
+		out, err = d.parseJSONByRecord(dec, sep)
+	case '{':
+		out, err = d.parseJSONByColumn(dec, sep)
+	default:
 		return []byte{}, errors.New("JSON does not conform to CSV encodings")
 	}
-	return d.parseJSONByColumn(byColumn)
+	if err != nil {
+		return []byte{}, err
+	}
+	return encodeOutput(out, cfg)
 }
 
 func appendIfMissing(slice []string, i string) []string {
@@ -251,15 +552,64 @@ func appendIfMissing(slice []string, i string) []string {
 	return append(slice, i)
 }
 
-func (d *JSONReader) parseJSONByRecord(v []map[string]interface{}, sep rune) ([]byte, error) {
-	nRows := len(v)
+func cellToString(val interface{}) string {
+	switch valData := val.(type) {
+	case float64:
+		// strconv.FormatFloat with -1 precision prints the shortest string
+		// that round-trips, so whole numbers come out as "1" rather than
+		// fmt.Sprintf's "1.000000".
+		return strconv.FormatFloat(valData, 'f', -1, 64)
+	case nil:
+		return ""
+	case string:
+		return fmt.Sprintf("%s", valData)
+	default:
+		return fmt.Sprintf("%v", valData)
+	}
+}
+
+// parseJSONByRecord decodes dec one JSON object at a time (dec is positioned
+// just past the opening '[') and keeps every record that passes Skip/Where/
+// Limit. Without Select the header is the union of those records' keys, so
+// all of them are held in memory before the first row is written to the
+// csv.Writer, which is then flushed after every row.
+func (d *JSONReader) parseJSONByRecord(dec *json.Decoder, sep rune) ([]byte, error) {
 	out := bytes.NewBuffer([]byte{})
 	w := csv.NewWriter(out)
 	w.Comma = sep
-	headers := []string{}
-	for _, record := range v {
-		for k := range record {
-			headers = appendIfMissing(headers, k)
+
+	records := []map[string]interface{}{}
+	seen, emitted := 0, 0
+	for dec.More() {
+		if d.limit >= 0 && emitted >= d.limit {
+			break
+		}
+		record := map[string]interface{}{}
+		if err := dec.Decode(&record); err != nil {
+			return []byte{}, err
+		}
+		if d.flattener != nil {
+			record = d.flattener.Flatten(record)
+		}
+		if seen < d.skip {
+			seen++
+			continue
+		}
+		seen++
+		if d.whereFn != nil && !d.whereFn(record) {
+			continue
+		}
+		emitted++
+		records = append(records, record)
+	}
+
+	headers := d.selectCols
+	if headers == nil {
+		headers = []string{}
+		for _, record := range records {
+			for k := range record {
+				headers = appendIfMissing(headers, k)
+			}
 		}
 	}
 	if !d.headersSet {
@@ -280,56 +630,62 @@ func (d *JSONReader) parseJSONByRecord(v []map[string]interface{}, sep rune) ([]
 			}
 		}
 		headers = d.expectedHeaders
-		if err := w.Write(headers); err != nil {
+		if err := w.Write(d.outputHeader(headers)); err != nil {
 			return []byte{}, err
 		}
 	}
-	nCols := len(headers)
-	data := make([][]string, nRows)
-	for i := 0; i < nRows; i++ {
-		data[i] = make([]string, nCols)
-	}
-	for rowNum, record := range v {
+
+	for _, record := range records {
+		row := make([]string, len(headers))
 		for colNum, header := range headers {
-			strVal := ""
-			val, ok := record[header]
-			if ok {
-				switch valData := val.(type) {
-				case int:
-					strVal = fmt.Sprintf("%d", valData)
-				case float64:
-					strVal = fmt.Sprintf("%f", valData)
-				case nil:
-					strVal = ""
-				case string:
-					strVal = fmt.Sprintf("%s", valData)
-				default:
-					strVal = fmt.Sprintf("%v", valData)
-				}
+			if val, ok := record[header]; ok {
+				row[colNum] = cellToString(val)
 			}
-			data[rowNum][colNum] = strVal
+		}
+		if err := w.Write(row); err != nil {
+			return []byte{}, err
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return []byte{}, err
 		}
 	}
-	if err := w.WriteAll(data); err != nil {
-		return []byte{}, err
-	}
-	w.Flush()
 	return out.Bytes(), nil
 }
 
-func (d *JSONReader) parseJSONByColumn(v map[string][]interface{}) ([]byte, error) {
+func (d *JSONReader) parseJSONByColumn(dec *json.Decoder, sep rune) ([]byte, error) {
+	v := map[string][]interface{}{}
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return []byte{}, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return []byte{}, errors.New("JSON does not conform to CSV encodings")
+		}
+		values := []interface{}{}
+		if err := dec.Decode(&values); err != nil {
+			return []byte{}, err
+		}
+		v[key] = values
+	}
+
 	out := bytes.NewBuffer([]byte{})
 	w := csv.NewWriter(out)
+	w.Comma = sep
 	maxLength := 0
-	keys := make([]string, 0, len(v))
-	colDone := map[string]bool{} // have we read all the values of this col?
-	for k, values := range v {
-		n := len(values)
-		if n > maxLength {
-			maxLength = n
+	keys := d.selectCols
+	if keys == nil {
+		keys = make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	for _, values := range v {
+		if len(values) > maxLength {
+			maxLength = len(values)
 		}
-		keys = append(keys, k)
-		colDone[k] = false
 	}
 	indexOf := func(slice []string, s string) int {
 		for i := 0; i < len(slice); i++ {
@@ -352,7 +708,7 @@ func (d *JSONReader) parseJSONByColumn(v map[string][]interface{}) ([]byte, erro
 		}
 	} else {
 		d.expectedHeaders = keys
-		if err := w.Write(keys); err != nil {
+		if err := w.Write(d.outputHeader(keys)); err != nil {
 			return []byte{}, err
 		}
 	}
@@ -361,24 +717,10 @@ func (d *JSONReader) parseJSONByColumn(v map[string][]interface{}) ([]byte, erro
 		row := make([]string, len(colnames))
 		for j, col := range colnames {
 			values := v[col]
-			if len(values) < i {
+			if len(values) <= i {
 				row[j] = ""
 			} else {
-				val := values[i]
-				strVal := ""
-				switch valData := val.(type) {
-				case int:
-					strVal = fmt.Sprintf("%d", valData)
-				case float64:
-					strVal = fmt.Sprintf("%f", valData)
-				case nil:
-					strVal = ""
-				case string:
-					strVal = fmt.Sprintf("%s", valData)
-				default:
-					strVal = fmt.Sprintf("%v", valData)
-				}
-				row[j] = strVal
+				row[j] = cellToString(values[i])
 			}
 		}
 		if err := w.Write(row); err != nil {
@@ -1,11 +1,19 @@
 package csvconv
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
 
+// nonSeekingReader hides the io.Seeker that strings.Reader implements so
+// tests can exercise the temp-file fallback in streamColumns.
+type nonSeekingReader struct {
+	io.Reader
+}
+
 type toJSONTest struct {
 	name   string
 	input  string
@@ -45,6 +53,29 @@ Resulting output:
 	}
 }
 
+func TestToJSONStream(t *testing.T) {
+	input := "a,b\n1,2\n4,\n"
+	want := `{"a":[1,4],"b":[2,null]}`
+
+	seekable := NewReader(strings.NewReader(input), ',')
+	var buf bytes.Buffer
+	if _, err := seekable.ToJSONStream(&buf, OrientColumns, -1); err != nil {
+		t.Fatalf("seekable: %s", err)
+	}
+	if buf.String() != want {
+		t.Errorf("seekable: got %s, want %s", buf.String(), want)
+	}
+
+	nonSeekable := NewReader(nonSeekingReader{strings.NewReader(input)}, ',')
+	buf.Reset()
+	if _, err := nonSeekable.ToJSONStream(&buf, OrientColumns, -1); err != nil {
+		t.Fatalf("non-seekable: %s", err)
+	}
+	if buf.String() != want {
+		t.Errorf("non-seekable: got %s, want %s", buf.String(), want)
+	}
+}
+
 type toCSVTest struct {
 	output string
 	input  string
@@ -0,0 +1,106 @@
+package csvconv
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding identifies a non-UTF-8 text encoding a CSV may be read from or
+// written in. The zero value, EncodingUTF8, means "no conversion".
+type Encoding int
+
+const (
+	EncodingUTF8 Encoding = iota
+	EncodingGBK
+	EncodingShiftJIS
+	EncodingWindows1252
+	// EncodingUTF16 auto-detects byte order from a leading BOM and assumes
+	// big-endian when none is present, per the UTF-16 spec.
+	EncodingUTF16
+)
+
+// codec returns the golang.org/x/text encoding.Encoding e represents, or nil
+// for EncodingUTF8 (no conversion needed).
+func (e Encoding) codec() encoding.Encoding {
+	switch e {
+	case EncodingGBK:
+		return simplifiedchinese.GBK
+	case EncodingShiftJIS:
+		return japanese.ShiftJIS
+	case EncodingWindows1252:
+		return charmap.Windows1252
+	case EncodingUTF16:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	default:
+		return nil
+	}
+}
+
+// ReaderOption configures a Reader at construction time.
+type ReaderOption func(*Reader)
+
+// WithEncoding decodes in as enc before the CSV parser ever sees it, for
+// CSVs that aren't UTF-8 (Excel exports in a local code page, Shift-JIS,
+// GBK, and so on). EncodingUTF16's decoder sniffs a leading BOM to pick
+// byte order, matching the behavior Excel itself expects when round-tripping
+// UTF-16 CSVs.
+func WithEncoding(enc Encoding) ReaderOption {
+	return func(r *Reader) {
+		codec := enc.codec()
+		if codec == nil {
+			return
+		}
+		r.in = transform.NewReader(r.in, codec.NewDecoder())
+	}
+}
+
+// csvOutputConfig holds the ToCSV options configured via CSVOption.
+type csvOutputConfig struct {
+	encoding Encoding
+	bom      bool
+}
+
+// CSVOption configures a JSONReader.ToCSV call.
+type CSVOption func(*csvOutputConfig)
+
+// WithOutputEncoding re-encodes ToCSV's output as enc instead of UTF-8.
+func WithOutputEncoding(enc Encoding) CSVOption {
+	return func(c *csvOutputConfig) { c.encoding = enc }
+}
+
+// WithBOM prefixes ToCSV's output with a UTF-8 byte-order mark, which Excel
+// uses to detect that a CSV without an explicit encoding is UTF-8. It only
+// applies when the output encoding is EncodingUTF8 (the default).
+func WithBOM() CSVOption {
+	return func(c *csvOutputConfig) { c.bom = true }
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// encodeOutput applies cfg's encoding and BOM settings to data, the UTF-8
+// CSV bytes ToCSV produced internally.
+func encodeOutput(data []byte, cfg csvOutputConfig) ([]byte, error) {
+	if cfg.encoding == EncodingUTF8 {
+		if cfg.bom {
+			data = append(append([]byte{}, utf8BOM...), data...)
+		}
+		return data, nil
+	}
+	encoded, _, err := transform.Bytes(cfg.encoding.codec().NewEncoder(), data)
+	return encoded, err
+}
+
+// stripBOM removes a leading UTF-8 byte-order mark from s, if present. CSVs
+// exported from Excel commonly start with one, which would otherwise end up
+// silently prepended to the first column name.
+func stripBOM(s string) string {
+	const bom = "\ufeff"
+	if len(s) >= len(bom) && s[:len(bom)] == bom {
+		return s[len(bom):]
+	}
+	return s
+}
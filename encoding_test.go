@@ -0,0 +1,76 @@
+package csvconv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+func TestReaderWithEncoding(t *testing.T) {
+	utf8 := "name,city\nJosé,Málaga\n"
+	win1252, _, err := transform.Bytes(charmap.Windows1252.NewEncoder(), []byte(utf8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(bytes.NewReader(win1252), ',', WithEncoding(EncodingWindows1252))
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"José", "Málaga"}; record[0] != want[0] || record[1] != want[1] {
+		t.Errorf("got %v, want %v", record, want)
+	}
+}
+
+func TestSetHeaderStripsBOM(t *testing.T) {
+	input := "\ufeffa,b\n1,2\n"
+	r := NewReader(strings.NewReader(input), ',')
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record[0] != "1" || record[1] != "2" {
+		t.Errorf("got %v", record)
+	}
+	if r.rawHeader[0] != "a" {
+		t.Errorf("header still has BOM: %q", r.rawHeader[0])
+	}
+}
+
+func TestToCSVWithBOM(t *testing.T) {
+	input := `[{"a":1}]`
+	d := NewJSONReader()
+	if _, err := d.ToCSV(strings.NewReader(input), ','); err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.ToCSV(strings.NewReader(input), ',', WithBOM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, utf8BOM) {
+		t.Errorf("output missing BOM: %q", out)
+	}
+}
+
+func TestToCSVWithOutputEncoding(t *testing.T) {
+	input := `[{"name":"José"}]`
+	d := NewJSONReader()
+	if _, err := d.ToCSV(strings.NewReader(input), ','); err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.ToCSV(strings.NewReader(input), ',', WithOutputEncoding(EncodingWindows1252))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := transform.Bytes(charmap.Windows1252.NewDecoder(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name\nJosé\n"; string(decoded) != want {
+		t.Errorf("got %q, want %q", decoded, want)
+	}
+}
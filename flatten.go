@@ -0,0 +1,108 @@
+package csvconv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Flattener turns nested JSON objects/arrays into flat, dot-separated keys
+// (and back), so nested payloads can round-trip through CSV's flat row
+// model: {"user":{"name":"a"}} becomes the column "user.name".
+type Flattener struct {
+	// Sep separates path segments. Defaults to "." when empty.
+	Sep string
+}
+
+// NewFlattener returns a Flattener using the default "." separator.
+func NewFlattener() *Flattener {
+	return &Flattener{Sep: "."}
+}
+
+func (f *Flattener) sep() string {
+	if f.Sep == "" {
+		return "."
+	}
+	return f.Sep
+}
+
+// Flatten walks v and returns a single-level map whose keys are dotted
+// paths, e.g. {"user":{"name":"a","addr":{"zip":"1"}}} becomes
+// {"user.name":"a","user.addr.zip":"1"}. Arrays are indexed the same way:
+// {"items":["a","b"]} becomes {"items.0":"a","items.1":"b"}.
+func (f *Flattener) Flatten(v map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, vv := range v {
+		f.flatten(k, vv, out)
+	}
+	return out
+}
+
+func (f *Flattener) flatten(prefix string, v interface{}, out map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range val {
+			f.flatten(prefix+f.sep()+k, vv, out)
+		}
+	case []interface{}:
+		for i, vv := range val {
+			f.flatten(prefix+f.sep()+strconv.Itoa(i), vv, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// Unflatten is Flatten's inverse: it splits each key on the separator and
+// rebuilds nested maps, turning any map whose keys are a dense 0..n-1
+// integer run into a slice.
+func (f *Flattener) Unflatten(flat map[string]interface{}) map[string]interface{} {
+	sep := f.sep()
+	root := map[string]interface{}{}
+	for key, val := range flat {
+		parts := strings.Split(key, sep)
+		cur := root
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				cur[part] = val
+				continue
+			}
+			next, ok := cur[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				cur[part] = next
+			}
+			cur = next
+		}
+	}
+	for k, v := range root {
+		root[k] = arrify(v)
+	}
+	return root
+}
+
+// arrify converts m into a []interface{} if every key parses as an integer
+// and together they form a dense 0..len(m)-1 run, recursing into children
+// first so nested arrays are converted too.
+func arrify(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k, vv := range m {
+		m[k] = arrify(vv)
+	}
+	seen := make([]bool, len(m))
+	for k := range m {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 || idx >= len(m) || seen[idx] {
+			return m
+		}
+		seen[idx] = true
+	}
+	arr := make([]interface{}, len(m))
+	for k, vv := range m {
+		idx, _ := strconv.Atoi(k)
+		arr[idx] = vv
+	}
+	return arr
+}
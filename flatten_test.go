@@ -0,0 +1,66 @@
+package csvconv
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenerRoundTrip(t *testing.T) {
+	nested := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "a",
+			"addr": map[string]interface{}{"zip": "1"},
+		},
+		"items": []interface{}{"x", "y"},
+	}
+	f := NewFlattener()
+	flat := f.Flatten(nested)
+	want := map[string]interface{}{
+		"user.name":     "a",
+		"user.addr.zip": "1",
+		"items.0":       "x",
+		"items.1":       "y",
+	}
+	if !reflect.DeepEqual(flat, want) {
+		t.Fatalf("Flatten: got %v, want %v", flat, want)
+	}
+	back := f.Unflatten(flat)
+	if !reflect.DeepEqual(back, nested) {
+		t.Fatalf("Unflatten: got %v, want %v", back, nested)
+	}
+}
+
+func TestJSONReaderFlattenToCSV(t *testing.T) {
+	input := `[{"user":{"name":"a","addr":{"zip":"1"}}}]`
+	d := NewJSONReader()
+	d.SetFlattener(NewFlattener())
+	out, err := d.ToCSV(strings.NewReader(input), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "a") || !strings.Contains(string(out), "1") {
+		t.Errorf("expected flattened values in output, got %q", out)
+	}
+}
+
+func TestReaderUnflattenToJSON(t *testing.T) {
+	input := "user.name,user.addr.zip\na,1\n"
+	r := NewReader(strings.NewReader(input), ',')
+	r.SetUnflatten(NewFlattener())
+	_, out, err := r.ToJSON(OrientRecords, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("output not valid JSON: %s: %s", err, out)
+	}
+	want := []map[string]interface{}{
+		{"user": map[string]interface{}{"name": "a", "addr": map[string]interface{}{"zip": float64(1)}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,416 @@
+package csvconv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Format is a pluggable sink for Reader.Convert: it receives the header
+// once, then one row at a time, and is finally closed so it can flush or
+// finalize any wrapped writer (e.g. gzip).
+type Format interface {
+	WriteHeader(header []string) error
+	WriteRow(row []string) error
+	Close() error
+}
+
+// cellEncoder is implemented by Formats whose cells should go through the
+// Reader's schema-aware encoding (see Reader.encodeCell) rather than raw
+// strings. Reader.Convert wires this up automatically.
+type cellEncoder interface {
+	setEncoder(func(colNum int, val string) string)
+}
+
+func defaultCellEncoder(_ int, val string) string {
+	return jsonCellValue(val)
+}
+
+type convertConfig struct {
+	nRows int
+}
+
+// Option configures a Reader.Convert call.
+type Option func(*convertConfig)
+
+// WithLimit caps the number of rows Convert reads. A negative n (the
+// default) means no limit.
+func WithLimit(n int) Option {
+	return func(c *convertConfig) { c.nRows = n }
+}
+
+// Convert reads rows and feeds them to format, which owns its own output
+// writer. It's the general-purpose counterpart to ToJSON/ToJSONStream: new
+// output formats are added by implementing Format rather than by adding
+// another Reader method.
+func (r *Reader) Convert(format Format, opts ...Option) (int, error) {
+	cfg := convertConfig{nRows: -1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !r.headerSet {
+		if err := r.setHeader(); err != nil {
+			return 0, err
+		}
+	}
+	if enc, ok := format.(cellEncoder); ok {
+		enc.setEncoder(r.encodeCell)
+	}
+	header := make([]string, r.nCols)
+	for i, h := range r.header {
+		name, err := strconv.Unquote(h)
+		if err != nil {
+			name = h
+		}
+		header[i] = name
+	}
+	if err := format.WriteHeader(header); err != nil {
+		return 0, err
+	}
+	nRows := cfg.nRows
+	if nRows < 0 {
+		nRows = math.MaxInt64
+	}
+	nRead := 0
+	for rowNum := 0; rowNum < nRows; rowNum++ {
+		record, err := r.Read()
+		if err != nil {
+			if rowNum == 0 || err != io.EOF {
+				return nRead, err
+			}
+			break
+		}
+		nRead++
+		if err := format.WriteRow(record); err != nil {
+			return nRead, err
+		}
+	}
+	return nRead, format.Close()
+}
+
+// FormatJSONRecords writes rows as a JSON array of objects, the same shape
+// as ToJSON(OrientRecords, ...).
+type FormatJSONRecords struct {
+	w      io.Writer
+	header []string
+	encode func(colNum int, val string) string
+	wrote  bool
+}
+
+// NewFormatJSONRecords returns a Format writing OrientRecords-shaped JSON to w.
+func NewFormatJSONRecords(w io.Writer) *FormatJSONRecords {
+	return &FormatJSONRecords{w: w, encode: defaultCellEncoder}
+}
+
+func (f *FormatJSONRecords) setEncoder(fn func(int, string) string) { f.encode = fn }
+
+func (f *FormatJSONRecords) WriteHeader(header []string) error {
+	f.header = header
+	_, err := io.WriteString(f.w, "[")
+	return err
+}
+
+func (f *FormatJSONRecords) WriteRow(row []string) error {
+	if f.wrote {
+		if _, err := io.WriteString(f.w, ","); err != nil {
+			return err
+		}
+	}
+	f.wrote = true
+	if _, err := io.WriteString(f.w, "{"); err != nil {
+		return err
+	}
+	for i, val := range row {
+		if i != 0 {
+			if _, err := io.WriteString(f.w, ","); err != nil {
+				return err
+			}
+		}
+		kv := strconv.Quote(f.header[i]) + ":" + f.encode(i, val)
+		if _, err := io.WriteString(f.w, kv); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(f.w, "}")
+	return err
+}
+
+func (f *FormatJSONRecords) Close() error {
+	_, err := io.WriteString(f.w, "]")
+	return err
+}
+
+// FormatJSONColumns writes rows as a JSON object of column arrays, the same
+// shape as ToJSON(OrientColumns, ...). Rows are spilled to temp files (see
+// Reader.streamColumnsTempFiles) until Close, since a column can't be
+// finished until every row has been seen.
+type FormatJSONColumns struct {
+	w      io.Writer
+	header []string
+	encode func(colNum int, val string) string
+	files  []*os.File
+	nRows  int
+}
+
+// NewFormatJSONColumns returns a Format writing OrientColumns-shaped JSON to w.
+func NewFormatJSONColumns(w io.Writer) *FormatJSONColumns {
+	return &FormatJSONColumns{w: w, encode: defaultCellEncoder}
+}
+
+func (f *FormatJSONColumns) setEncoder(fn func(int, string) string) { f.encode = fn }
+
+func (f *FormatJSONColumns) WriteHeader(header []string) error {
+	f.header = header
+	f.files = make([]*os.File, len(header))
+	for i := range f.files {
+		tf, err := ioutil.TempFile("", "csvconv-col-*")
+		if err != nil {
+			return err
+		}
+		f.files[i] = tf
+	}
+	return nil
+}
+
+func (f *FormatJSONColumns) WriteRow(row []string) error {
+	for i, val := range row {
+		if f.nRows != 0 {
+			if _, err := io.WriteString(f.files[i], ","); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(f.files[i], f.encode(i, val)); err != nil {
+			return err
+		}
+	}
+	f.nRows++
+	return nil
+}
+
+func (f *FormatJSONColumns) Close() error {
+	defer func() {
+		for _, tf := range f.files {
+			tf.Close()
+			os.Remove(tf.Name())
+		}
+	}()
+	if _, err := io.WriteString(f.w, "{"); err != nil {
+		return err
+	}
+	for i, tf := range f.files {
+		if _, err := io.WriteString(f.w, strconv.Quote(f.header[i])+":["); err != nil {
+			return err
+		}
+		if _, err := tf.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(f.w, tf); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f.w, "]"); err != nil {
+			return err
+		}
+		if i < len(f.files)-1 {
+			if _, err := io.WriteString(f.w, ","); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(f.w, "}")
+	return err
+}
+
+// FormatNDJSON writes one JSON object per line (newline-delimited JSON),
+// the standard format for log pipelines and streaming `jq` consumers.
+type FormatNDJSON struct {
+	w      io.Writer
+	header []string
+	encode func(colNum int, val string) string
+}
+
+// NewFormatNDJSON returns a Format writing one JSON object per line to w.
+func NewFormatNDJSON(w io.Writer) *FormatNDJSON {
+	return &FormatNDJSON{w: w, encode: defaultCellEncoder}
+}
+
+func (f *FormatNDJSON) setEncoder(fn func(int, string) string) { f.encode = fn }
+
+func (f *FormatNDJSON) WriteHeader(header []string) error {
+	f.header = header
+	return nil
+}
+
+func (f *FormatNDJSON) WriteRow(row []string) error {
+	if _, err := io.WriteString(f.w, "{"); err != nil {
+		return err
+	}
+	for i, val := range row {
+		if i != 0 {
+			if _, err := io.WriteString(f.w, ","); err != nil {
+				return err
+			}
+		}
+		kv := strconv.Quote(f.header[i]) + ":" + f.encode(i, val)
+		if _, err := io.WriteString(f.w, kv); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(f.w, "}\n")
+	return err
+}
+
+func (f *FormatNDJSON) Close() error { return nil }
+
+// FormatJSONLinesGzip is FormatNDJSON gzip-compressed as it's written.
+type FormatJSONLinesGzip struct {
+	gz *gzip.Writer
+	nd *FormatNDJSON
+}
+
+// NewFormatJSONLinesGzip returns a Format writing gzip-compressed NDJSON to w.
+func NewFormatJSONLinesGzip(w io.Writer) *FormatJSONLinesGzip {
+	gz := gzip.NewWriter(w)
+	return &FormatJSONLinesGzip{gz: gz, nd: NewFormatNDJSON(gz)}
+}
+
+func (f *FormatJSONLinesGzip) setEncoder(fn func(int, string) string) { f.nd.setEncoder(fn) }
+
+func (f *FormatJSONLinesGzip) WriteHeader(header []string) error { return f.nd.WriteHeader(header) }
+
+func (f *FormatJSONLinesGzip) WriteRow(row []string) error { return f.nd.WriteRow(row) }
+
+func (f *FormatJSONLinesGzip) Close() error {
+	if err := f.nd.Close(); err != nil {
+		return err
+	}
+	return f.gz.Close()
+}
+
+// FormatTSV writes tab-separated values.
+type FormatTSV struct {
+	cw *csv.Writer
+}
+
+// NewFormatTSV returns a Format writing TSV to w.
+func NewFormatTSV(w io.Writer) *FormatTSV {
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	return &FormatTSV{cw: cw}
+}
+
+func (f *FormatTSV) WriteHeader(header []string) error { return f.cw.Write(header) }
+
+func (f *FormatTSV) WriteRow(row []string) error { return f.cw.Write(row) }
+
+func (f *FormatTSV) Close() error {
+	f.cw.Flush()
+	return f.cw.Error()
+}
+
+// InputFormat identifies how JSON is laid out for JSONReader.ConvertFrom.
+type InputFormat int
+
+const (
+	// InputAuto sniffs the first token like ToCSV: a top-level array is
+	// read as records, a top-level object as columns.
+	InputAuto InputFormat = iota
+	// InputNDJSON reads one JSON object per line.
+	InputNDJSON
+)
+
+// ConvertFrom decodes JSON from r according to format and writes CSV to w,
+// using sep as the field separator. Unlike ToCSV, NDJSON input is decoded
+// incrementally line by line via a bufio.Scanner rather than buffered and
+// parsed as a single document.
+func (d *JSONReader) ConvertFrom(format InputFormat, r io.Reader, w io.Writer, sep rune) error {
+	switch format {
+	case InputNDJSON:
+		return d.ndjsonToCSV(r, w, sep)
+	default:
+		out, err := d.ToCSV(r, sep)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+}
+
+// ndjsonToCSV streams NDJSON lines straight to CSV. When no column order is
+// requested, the header is taken from the keys of the first emitted record
+// (sorted for a deterministic order) and is not widened for keys that only
+// appear in later records.
+func (d *JSONReader) ndjsonToCSV(r io.Reader, w io.Writer, sep rune) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+
+	headerWritten := false
+	seen, emitted := 0, 0
+	for scanner.Scan() {
+		if d.limit >= 0 && emitted >= d.limit {
+			break
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		if d.flattener != nil {
+			record = d.flattener.Flatten(record)
+		}
+		if seen < d.skip {
+			seen++
+			continue
+		}
+		seen++
+		if d.whereFn != nil && !d.whereFn(record) {
+			continue
+		}
+		emitted++
+		if !d.headersSet {
+			headers := d.selectCols
+			if headers == nil {
+				headers = make([]string, 0, len(record))
+				for k := range record {
+					headers = append(headers, k)
+				}
+				sort.Strings(headers)
+			}
+			d.headersSet = true
+			d.expectedHeaders = headers
+		}
+		if !headerWritten {
+			if err := cw.Write(d.outputHeader(d.expectedHeaders)); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+		row := make([]string, len(d.expectedHeaders))
+		for i, h := range d.expectedHeaders {
+			if val, ok := record[h]; ok {
+				row[i] = cellToString(val)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,79 @@
+package csvconv
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConvertFormats(t *testing.T) {
+	input := "a,b\n1,x\n2,y\n"
+
+	var records bytes.Buffer
+	r := NewReader(strings.NewReader(input), ',')
+	if _, err := r.Convert(NewFormatJSONRecords(&records)); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"a":1,"b":"x"},{"a":2,"b":"y"}]`; records.String() != want {
+		t.Errorf("records: got %s, want %s", records.String(), want)
+	}
+
+	var columns bytes.Buffer
+	r2 := NewReader(strings.NewReader(input), ',')
+	if _, err := r2.Convert(NewFormatJSONColumns(&columns)); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":[1,2],"b":["x","y"]}`; columns.String() != want {
+		t.Errorf("columns: got %s, want %s", columns.String(), want)
+	}
+
+	var ndjson bytes.Buffer
+	r3 := NewReader(strings.NewReader(input), ',')
+	if _, err := r3.Convert(NewFormatNDJSON(&ndjson)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":1,\"b\":\"x\"}\n{\"a\":2,\"b\":\"y\"}\n"; ndjson.String() != want {
+		t.Errorf("ndjson: got %s, want %s", ndjson.String(), want)
+	}
+
+	var tsv bytes.Buffer
+	r4 := NewReader(strings.NewReader(input), ',')
+	if _, err := r4.Convert(NewFormatTSV(&tsv)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\tb\n1\tx\n2\ty\n"; tsv.String() != want {
+		t.Errorf("tsv: got %q, want %q", tsv.String(), want)
+	}
+
+	var gz bytes.Buffer
+	r5 := NewReader(strings.NewReader(input), ',')
+	if _, err := r5.Convert(NewFormatJSONLinesGzip(&gz)); err != nil {
+		t.Fatal(err)
+	}
+	gr, err := gzip.NewReader(&gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := ioutil.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":1,\"b\":\"x\"}\n{\"a\":2,\"b\":\"y\"}\n"; string(decompressed) != want {
+		t.Errorf("gzip ndjson: got %s, want %s", decompressed, want)
+	}
+}
+
+func TestJSONReaderConvertFromNDJSON(t *testing.T) {
+	input := "{\"a\":1,\"b\":\"x\"}\n{\"a\":2,\"b\":\"y\"}\n"
+	d := NewJSONReader()
+	var out bytes.Buffer
+	if err := d.ConvertFrom(InputNDJSON, strings.NewReader(input), &out, ','); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || lines[0] != "a,b" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
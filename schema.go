@@ -0,0 +1,199 @@
+package csvconv
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// ColKind identifies the narrowest JSON-compatible type a CSV column's
+// values can be represented as.
+type ColKind int
+
+const (
+	KindString ColKind = iota
+	KindInt
+	KindFloat
+	KindBool
+	KindDate
+	KindNull
+)
+
+// ColumnSchema describes the inferred or declared type of a single CSV
+// column.
+type ColumnSchema struct {
+	Name string
+	Kind ColKind
+}
+
+var defaultNullTokens = []string{""}
+
+// dateLayouts are tried, in order, when sniffing or parsing a date column.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+}
+
+// SetNullTokens overrides the set of raw cell values treated as JSON null
+// during emission and schema inference. A Reader with no tokens set treats
+// only the empty string as null.
+func (r *Reader) SetNullTokens(tokens []string) {
+	r.nullTokens = tokens
+}
+
+func (r *Reader) isNullToken(val string) bool {
+	tokens := r.nullTokens
+	if tokens == nil {
+		tokens = defaultNullTokens
+	}
+	for _, t := range tokens {
+		if val == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSchema overrides schema inference, forcing ToJSON/ToJSONStream to
+// encode each column according to the given kinds. The slice is positional:
+// schema[i] describes column i.
+func (r *Reader) SetSchema(schema []ColumnSchema) {
+	r.schema = schema
+}
+
+// InferSchema scans up to maxSampleRows rows (or all remaining rows if
+// maxSampleRows < 0) and, for each column, picks the narrowest ColKind that
+// accepts every observed non-null value. Rows consumed while sampling are
+// buffered and replayed by subsequent Read/ToJSON calls, so InferSchema can
+// be called before processing the data without losing rows. The inferred
+// schema is also stashed on r, so a plain ToJSON call right after
+// InferSchema picks it up automatically.
+func (r *Reader) InferSchema(maxSampleRows int) ([]ColumnSchema, error) {
+	if !r.headerSet {
+		if err := r.setHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	type columnStats struct {
+		isInt, isFloat, isBool, isDate, sawValue bool
+	}
+	stats := make([]columnStats, r.nCols)
+	for i := range stats {
+		stats[i] = columnStats{isInt: true, isFloat: true, isBool: true, isDate: true}
+	}
+
+	for rowNum := 0; maxSampleRows < 0 || rowNum < maxSampleRows; rowNum++ {
+		// fetchNext bypasses r.pending, which is exactly what we're filling
+		// below; Read() would otherwise just hand the same row back forever.
+		record, err := r.fetchNext()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		r.pending = append(r.pending, record)
+		for colNum, val := range record {
+			if r.isNullToken(val) {
+				continue
+			}
+			s := &stats[colNum]
+			s.sawValue = true
+			if s.isInt {
+				if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+					s.isInt = false
+				}
+			}
+			if s.isFloat {
+				if _, err := strconv.ParseFloat(val, 64); err != nil {
+					s.isFloat = false
+				}
+			}
+			if s.isBool {
+				if _, err := strconv.ParseBool(val); err != nil {
+					s.isBool = false
+				}
+			}
+			if s.isDate && !looksLikeDate(val) {
+				s.isDate = false
+			}
+		}
+	}
+
+	schema := make([]ColumnSchema, r.nCols)
+	for colNum := range schema {
+		name, err := strconv.Unquote(r.header[colNum])
+		if err != nil {
+			name = r.header[colNum]
+		}
+		s := stats[colNum]
+		kind := KindString
+		switch {
+		case !s.sawValue:
+			kind = KindNull
+		case s.isInt:
+			kind = KindInt
+		case s.isFloat:
+			kind = KindFloat
+		case s.isBool:
+			kind = KindBool
+		case s.isDate:
+			kind = KindDate
+		}
+		schema[colNum] = ColumnSchema{Name: name, Kind: kind}
+	}
+	r.schema = schema
+	return schema, nil
+}
+
+func looksLikeDate(val string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, val); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCell renders the cell at colNum as a JSON token, consulting r's
+// schema (if any) and null tokens before falling back to the untyped
+// number-or-string heuristic.
+func (r *Reader) encodeCell(colNum int, val string) string {
+	if r.isNullToken(val) {
+		return "null"
+	}
+	if r.schema != nil && colNum < len(r.schema) {
+		return schemaCellValue(val, r.schema[colNum].Kind)
+	}
+	return jsonCellValue(val)
+}
+
+// schemaCellValue renders val as a JSON token according to kind, falling
+// back to a quoted string if val doesn't actually fit kind.
+func schemaCellValue(val string, kind ColKind) string {
+	switch kind {
+	case KindInt:
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+	case KindFloat:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	case KindBool:
+		if b, err := strconv.ParseBool(val); err == nil {
+			return strconv.FormatBool(b)
+		}
+	case KindDate:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, val); err == nil {
+				return strconv.Quote(t.Format(time.RFC3339))
+			}
+		}
+	case KindNull:
+		return "null"
+	}
+	return strconv.Quote(val)
+}
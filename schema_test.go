@@ -0,0 +1,47 @@
+package csvconv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	input := "id,price,active,when,name\n1,2.5,true,2020-01-02,bob\n2,3,false,2020-01-03,ann\n"
+	r := NewReader(strings.NewReader(input), ',')
+	schema, err := r.InferSchema(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColKind{KindInt, KindFloat, KindBool, KindDate, KindString}
+	if len(schema) != len(want) {
+		t.Fatalf("expected %d columns, got %d", len(want), len(schema))
+	}
+	for i, kind := range want {
+		if schema[i].Kind != kind {
+			t.Errorf("column %d (%s): got kind %d, want %d", i, schema[i].Name, schema[i].Kind, kind)
+		}
+	}
+
+	// rows consumed during inference must still be readable afterwards
+	_, jsonData, err := r.ToJSON(OrientRecords, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want2 := `[{"id":1,"price":2.5,"active":true,"when":"2020-01-02T00:00:00Z","name":"bob"},{"id":2,"price":3,"active":false,"when":"2020-01-03T00:00:00Z","name":"ann"}]`
+	if string(jsonData) != want2 {
+		t.Errorf("got %s, want %s", jsonData, want2)
+	}
+}
+
+func TestSetSchemaOverride(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n1,x\n"), ',')
+	r.SetSchema([]ColumnSchema{{Name: "a", Kind: KindFloat}, {Name: "b", Kind: KindString}})
+	_, jsonData, err := r.ToJSON(OrientRecords, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"a":1,"b":"x"}]`
+	if string(jsonData) != want {
+		t.Errorf("got %s, want %s", jsonData, want)
+	}
+}
@@ -0,0 +1,217 @@
+package csvconv
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Select restricts output to the named columns, in the given order. It must
+// be called before the first Read/ToJSON call, since the header (and
+// column layout) is only resolved once, on first use.
+func (r *Reader) Select(cols ...string) *Reader {
+	r.selectCols = cols
+	return r
+}
+
+// Rename maps original column names to output names. Like Select, it must
+// be configured before the first Read/ToJSON call.
+func (r *Reader) Rename(mapping map[string]string) *Reader {
+	r.renameMap = mapping
+	return r
+}
+
+// Where filters rows: only records for which fn returns true are read.
+// record is keyed by the CSV's original column names, regardless of any
+// configured Select/Rename.
+func (r *Reader) Where(fn func(record map[string]string) bool) *Reader {
+	r.whereFn = fn
+	return r
+}
+
+// Limit caps the number of rows Read returns (after Where/Skip). A negative
+// n, the default, means no limit.
+func (r *Reader) Limit(n int) *Reader {
+	r.limit = n
+	return r
+}
+
+// Skip discards the first n rows read, before the Where filter is applied;
+// the discarded rows are not passed to Where.
+func (r *Reader) Skip(n int) *Reader {
+	r.skip = n
+	return r
+}
+
+// Select restricts output to the named JSON keys.
+func (d *JSONReader) Select(cols ...string) *JSONReader {
+	d.selectCols = cols
+	return d
+}
+
+// Rename maps JSON keys to output CSV column names.
+func (d *JSONReader) Rename(mapping map[string]string) *JSONReader {
+	d.renameMap = mapping
+	return d
+}
+
+// Where filters records: only those for which fn returns true are written
+// to CSV. Only the records orientation (top-level JSON array) supports
+// Where; it's ignored for the by-column orientation, which has no
+// per-record view of the data.
+func (d *JSONReader) Where(fn func(record map[string]interface{}) bool) *JSONReader {
+	d.whereFn = fn
+	return d
+}
+
+// Limit caps the number of records written (after Where/Skip). Like Where,
+// it is ignored for the by-column orientation.
+func (d *JSONReader) Limit(n int) *JSONReader {
+	d.limit = n
+	return d
+}
+
+// Skip discards the first n records decoded, before the Where filter is
+// applied. Like Where, it is ignored for the by-column orientation.
+func (d *JSONReader) Skip(n int) *JSONReader {
+	d.skip = n
+	return d
+}
+
+// outputHeader renames lookup (the JSON keys used to pull values out of a
+// record) into the CSV column names actually written.
+func (d *JSONReader) outputHeader(lookup []string) []string {
+	out := make([]string, len(lookup))
+	for i, h := range lookup {
+		name := h
+		if renamed, ok := d.renameMap[h]; ok {
+			name = renamed
+		}
+		out[i] = name
+	}
+	return out
+}
+
+// Agg describes a single column aggregation for Reader.Aggregate.
+type Agg struct {
+	op  string
+	col string
+}
+
+// Count aggregates the number of rows read (count(*)).
+func Count() Agg { return Agg{op: "count"} }
+
+// Sum aggregates the sum of col's numeric values.
+func Sum(col string) Agg { return Agg{op: "sum", col: col} }
+
+// Min aggregates the minimum of col's numeric values.
+func Min(col string) Agg { return Agg{op: "min", col: col} }
+
+// Max aggregates the maximum of col's numeric values.
+func Max(col string) Agg { return Agg{op: "max", col: col} }
+
+// Avg aggregates the mean of col's numeric values.
+func Avg(col string) Agg { return Agg{op: "avg", col: col} }
+
+func (a Agg) label() string {
+	if a.op == "count" {
+		return "count(*)"
+	}
+	return a.op + "(" + a.col + ")"
+}
+
+type aggState struct {
+	Agg
+	idx      int
+	count    int
+	sum      float64
+	min, max float64
+	seen     bool
+}
+
+// Aggregate streams every (Where/Select-filtered) row through aggs and
+// returns one value per aggregation, keyed by a label like "sum(price)" or
+// "count(*)". Rows are never materialized: each aggregation keeps only its
+// running total.
+func (r *Reader) Aggregate(aggs ...Agg) (map[string]float64, error) {
+	if !r.headerSet {
+		if err := r.setHeader(); err != nil {
+			return nil, err
+		}
+	}
+	states := make([]aggState, len(aggs))
+	for i, a := range aggs {
+		states[i] = aggState{Agg: a}
+		if a.op != "count" {
+			idx, err := r.outputColumnIndex(a.col)
+			if err != nil {
+				return nil, err
+			}
+			states[i].idx = idx
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for i := range states {
+			s := &states[i]
+			if s.op == "count" {
+				s.count++
+				continue
+			}
+			val, err := strconv.ParseFloat(record[s.idx], 64)
+			if err != nil {
+				continue
+			}
+			s.count++
+			s.sum += val
+			if !s.seen || val < s.min {
+				s.min = val
+			}
+			if !s.seen || val > s.max {
+				s.max = val
+			}
+			s.seen = true
+		}
+	}
+
+	result := make(map[string]float64, len(states))
+	for _, s := range states {
+		switch s.op {
+		case "count":
+			result[s.label()] = float64(s.count)
+		case "sum":
+			result[s.label()] = s.sum
+		case "min":
+			result[s.label()] = s.min
+		case "max":
+			result[s.label()] = s.max
+		case "avg":
+			if s.count > 0 {
+				result[s.label()] = s.sum / float64(s.count)
+			}
+		}
+	}
+	return result, nil
+}
+
+// outputColumnIndex finds name's position among r's (possibly
+// Select/Rename-projected) output columns.
+func (r *Reader) outputColumnIndex(name string) (int, error) {
+	for i, h := range r.header {
+		unquoted, err := strconv.Unquote(h)
+		if err != nil {
+			unquoted = h
+		}
+		if unquoted == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("csvconv: aggregate: unknown column %q", name)
+}
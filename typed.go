@@ -0,0 +1,355 @@
+package csvconv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Unmarshaler lets a type take full control over how a single CSV cell is
+// decoded into it.
+type Unmarshaler interface {
+	UnmarshalCSV(string) error
+}
+
+// Marshaler lets a type take full control over how it's encoded into a
+// single CSV cell.
+type Marshaler interface {
+	MarshalCSV() (string, error)
+}
+
+// csvField describes how one struct field maps onto a CSV column.
+type csvField struct {
+	name      string
+	omitempty bool
+	index     int
+}
+
+// csvTag parses a `csv:"name,omitempty"` struct tag. ok is false if the
+// field should be skipped entirely (tag is "-").
+func csvTag(f reflect.StructField) (name string, omitempty bool, ok bool) {
+	tag, tagged := f.Tag.Lookup("csv")
+	if !tagged {
+		return f.Name, false, true
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" {
+		return "", false, false
+	}
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// csvFields reflects on T (which must be a struct type) and returns its
+// csv-tagged fields in declaration order.
+func csvFields(t reflect.Type) ([]csvField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csvconv: %s is not a struct", t)
+	}
+	fields := make([]csvField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		name, omitempty, ok := csvTag(sf)
+		if !ok {
+			continue
+		}
+		fields = append(fields, csvField{name: name, omitempty: omitempty, index: i})
+	}
+	return fields, nil
+}
+
+// TypedReader binds CSV rows to a struct type T using `csv:"name"` tags.
+type TypedReader[T any] struct {
+	cr        *csv.Reader
+	fields    []csvField
+	colFields []*csvField // one entry per CSV column, nil if unmapped
+	header    []string
+	from, to  int
+}
+
+// NewTypedReader creates a TypedReader that decodes rows from r into values
+// of type T. T must be a struct type.
+func NewTypedReader[T any](r io.Reader, sep rune) *TypedReader[T] {
+	cr := csv.NewReader(r)
+	cr.Comma = sep
+	cr.TrimLeadingSpace = true
+	return &TypedReader[T]{cr: cr, to: -1}
+}
+
+// From restricts decoding to rows at index n or later (0-based, header not
+// counted).
+func (tr *TypedReader[T]) From(n int) *TypedReader[T] {
+	tr.from = n
+	return tr
+}
+
+// To restricts decoding to rows before index n (0-based, exclusive).
+func (tr *TypedReader[T]) To(n int) *TypedReader[T] {
+	tr.to = n
+	return tr
+}
+
+func (tr *TypedReader[T]) readHeader() error {
+	if tr.header != nil {
+		return nil
+	}
+	header, err := tr.cr.Read()
+	if err != nil {
+		return err
+	}
+	var zero T
+	fields, err := csvFields(reflect.TypeOf(zero))
+	if err != nil {
+		return err
+	}
+	tr.fields = fields
+	tr.header = header
+	tr.colFields = make([]*csvField, len(header))
+	for col, name := range header {
+		for i := range fields {
+			if fields[i].name == name {
+				tr.colFields[col] = &fields[i]
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// inRange reports whether row rowNum (0-based, header excluded) passes the
+// configured From/To bounds.
+func (tr *TypedReader[T]) inRange(rowNum int) bool {
+	if rowNum < tr.from {
+		return false
+	}
+	if tr.to >= 0 && rowNum >= tr.to {
+		return false
+	}
+	return true
+}
+
+func (tr *TypedReader[T]) decodeRow(record []string) (T, error) {
+	var out T
+	v := reflect.ValueOf(&out).Elem()
+	for col, val := range record {
+		field := tr.colFields[col]
+		if field == nil {
+			continue
+		}
+		if err := setCSVValue(v.Field(field.index), val); err != nil {
+			return out, fmt.Errorf("csvconv: column %q: %w", field.name, err)
+		}
+	}
+	return out, nil
+}
+
+// ReadAll decodes every in-range row into a []T.
+func (tr *TypedReader[T]) ReadAll() ([]T, error) {
+	if err := tr.readHeader(); err != nil {
+		return nil, err
+	}
+	out := []T{}
+	for rowNum := 0; tr.to < 0 || rowNum < tr.to; rowNum++ {
+		record, err := tr.cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if !tr.inRange(rowNum) {
+			continue
+		}
+		row, err := tr.decodeRow(record)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// ReadEach streams decoded rows to ch, closing it once the input is
+// exhausted or an error occurs.
+func (tr *TypedReader[T]) ReadEach(ch chan<- T) error {
+	defer close(ch)
+	if err := tr.readHeader(); err != nil {
+		return err
+	}
+	for rowNum := 0; tr.to < 0 || rowNum < tr.to; rowNum++ {
+		record, err := tr.cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if !tr.inRange(rowNum) {
+			continue
+		}
+		row, err := tr.decodeRow(record)
+		if err != nil {
+			return err
+		}
+		ch <- row
+	}
+	return nil
+}
+
+// setCSVValue decodes val into field, dispatching to field's Unmarshaler if
+// it implements one.
+func setCSVValue(field reflect.Value, val string) error {
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(Unmarshaler); ok {
+			return u.UnmarshalCSV(val)
+		}
+	}
+	if field.Kind() == reflect.Ptr {
+		if val == "" {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		elem := reflect.New(field.Type().Elem())
+		if err := setCSVValue(elem.Elem(), val); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
+
+// TypedWriter encodes values of struct type T to CSV using `csv:"name"`
+// tags.
+type TypedWriter[T any] struct {
+	cw            *csv.Writer
+	fields        []csvField
+	headerWritten bool
+}
+
+// NewTypedWriter creates a TypedWriter that encodes values of type T to w.
+// T must be a struct type.
+func NewTypedWriter[T any](w io.Writer, sep rune) *TypedWriter[T] {
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	return &TypedWriter[T]{cw: cw}
+}
+
+func (tw *TypedWriter[T]) writeHeader() error {
+	if tw.headerWritten {
+		return nil
+	}
+	var zero T
+	fields, err := csvFields(reflect.TypeOf(zero))
+	if err != nil {
+		return err
+	}
+	tw.fields = fields
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	tw.headerWritten = true
+	return tw.cw.Write(header)
+}
+
+// WriteAll writes the header (on first use) followed by one row per value
+// in rows, then flushes.
+func (tw *TypedWriter[T]) WriteAll(rows []T) error {
+	if err := tw.writeHeader(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		v := reflect.ValueOf(&row).Elem()
+		record := make([]string, len(tw.fields))
+		for i, f := range tw.fields {
+			val, err := csvValue(v.Field(f.index), f.omitempty)
+			if err != nil {
+				return fmt.Errorf("csvconv: column %q: %w", f.name, err)
+			}
+			record[i] = val
+		}
+		if err := tw.cw.Write(record); err != nil {
+			return err
+		}
+	}
+	tw.cw.Flush()
+	return tw.cw.Error()
+}
+
+// csvValue encodes field as a single CSV cell, dispatching to its
+// Marshaler if it implements one.
+func csvValue(field reflect.Value, omitempty bool) (string, error) {
+	if field.CanAddr() {
+		if m, ok := field.Addr().Interface().(Marshaler); ok {
+			return m.MarshalCSV()
+		}
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "", nil
+		}
+		return csvValue(field.Elem(), omitempty)
+	}
+	if omitempty && field.IsZero() {
+		return "", nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+}
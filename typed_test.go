@@ -0,0 +1,61 @@
+package csvconv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type typedTestRow struct {
+	Name string  `csv:"name"`
+	Age  int     `csv:"age"`
+	City *string `csv:"city"`
+	Bio  string  `csv:"bio,omitempty"`
+}
+
+func TestTypedReaderReadAll(t *testing.T) {
+	input := "name,age,city,bio\nbob,30,nyc,hi\nann,25,,\n"
+	tr := NewTypedReader[typedTestRow](strings.NewReader(input), ',')
+	rows, err := tr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Name != "bob" || rows[0].Age != 30 || rows[0].City == nil || *rows[0].City != "nyc" {
+		t.Errorf("unexpected row 0: %+v", rows[0])
+	}
+	if rows[1].City != nil {
+		t.Errorf("expected nil city for row 1, got %v", *rows[1].City)
+	}
+}
+
+func TestTypedReaderFromTo(t *testing.T) {
+	input := "name,age,city,bio\na,1,,\nb,2,,\nc,3,,\n"
+	tr := NewTypedReader[typedTestRow](strings.NewReader(input), ',').From(1).To(2)
+	rows, err := tr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Name != "b" {
+		t.Fatalf("expected just row 'b', got %+v", rows)
+	}
+}
+
+func TestTypedWriterWriteAll(t *testing.T) {
+	nyc := "nyc"
+	rows := []typedTestRow{
+		{Name: "bob", Age: 30, City: &nyc, Bio: "hi"},
+		{Name: "ann", Age: 25, City: nil, Bio: ""},
+	}
+	var buf bytes.Buffer
+	tw := NewTypedWriter[typedTestRow](&buf, ',')
+	if err := tw.WriteAll(rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,age,city,bio\nbob,30,nyc,hi\nann,25,,\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}